@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func TestComputeExternalAccountBindingJWS(t *testing.T) {
+	key, _, err := generateAcmeAccountKey()
+	if err != nil {
+		t.Fatalf("generateAcmeAccountKey: %+v", err)
+	}
+
+	hmacKey := []byte("super-secret-hmac-key")
+	eab := &acmeExternalAccountBinding{
+		KeyID:   "kid-123",
+		HMACKey: base64.RawURLEncoding.EncodeToString(hmacKey),
+	}
+	const url = "https://admin.example.com/api/acme/accounts/abc"
+
+	binding, err := computeExternalAccountBindingJWS(eab, &key.PublicKey, url)
+	if err != nil {
+		t.Fatalf("computeExternalAccountBindingJWS: %+v", err)
+	}
+
+	protected, err := base64.RawURLEncoding.DecodeString(binding.Protected)
+	if err != nil {
+		t.Fatalf("decoding protected header: %+v", err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(protected, &header); err != nil {
+		t.Fatalf("unmarshalling protected header: %+v", err)
+	}
+	if header.Alg != "HS256" || header.Kid != eab.KeyID || header.URL != url {
+		t.Errorf("unexpected protected header: %+v", header)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(binding.Payload)
+	if err != nil {
+		t.Fatalf("decoding payload: %+v", err)
+	}
+	wantPayload, err := accountKeyJWK(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("accountKeyJWK: %+v", err)
+	}
+	if string(payload) != string(wantPayload) {
+		t.Errorf("payload = %s, want %s", payload, wantPayload)
+	}
+
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write([]byte(binding.Protected + "." + binding.Payload))
+	wantSignature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if binding.Signature != wantSignature {
+		t.Errorf("signature = %s, want %s", binding.Signature, wantSignature)
+	}
+}
+
+func TestComputeExternalAccountBindingJWSRequiresKidAndHMACKey(t *testing.T) {
+	key, _, err := generateAcmeAccountKey()
+	if err != nil {
+		t.Fatalf("generateAcmeAccountKey: %+v", err)
+	}
+
+	cases := []*acmeExternalAccountBinding{
+		{KeyID: "", HMACKey: "a2V5"},
+		{KeyID: "kid", HMACKey: ""},
+	}
+	for _, eab := range cases {
+		if _, err := computeExternalAccountBindingJWS(eab, &key.PublicKey, "https://example.com"); err == nil {
+			t.Errorf("computeExternalAccountBindingJWS(%+v) = nil error, want error", eab)
+		}
+	}
+}
+
+func TestComputeExternalAccountBindingJWSInvalidHMACKey(t *testing.T) {
+	key, _, err := generateAcmeAccountKey()
+	if err != nil {
+		t.Fatalf("generateAcmeAccountKey: %+v", err)
+	}
+	eab := &acmeExternalAccountBinding{KeyID: "kid", HMACKey: "not-valid-base64url!!"}
+	if _, err := computeExternalAccountBindingJWS(eab, &key.PublicKey, "https://example.com"); err == nil {
+		t.Error("computeExternalAccountBindingJWS with invalid hmacKey = nil error, want error")
+	}
+}
+
+func TestAcmeAccountStore(t *testing.T) {
+	store := &acmeAccountStore{accounts: map[string]*acmeAccount{}}
+
+	account := &acmeAccount{ID: "abc", Contact: []string{"mailto:ops@example.com"}}
+	store.save(account)
+
+	if got, ok := store.get("abc"); !ok || got != account {
+		t.Fatalf("get(%q) = %+v, %v; want %+v, true", "abc", got, ok, account)
+	}
+	if found, ok := store.findByContact([]string{"mailto:ops@example.com"}); !ok || found != account {
+		t.Fatalf("findByContact = %+v, %v; want %+v, true", found, ok, account)
+	}
+	if _, ok := store.findByContact([]string{"mailto:someone-else@example.com"}); ok {
+		t.Error("findByContact matched an unrelated contact list")
+	}
+	if len(store.list()) != 1 {
+		t.Errorf("list() = %v, want 1 account", store.list())
+	}
+	if !store.delete("abc") {
+		t.Error("delete(\"abc\") = false, want true")
+	}
+	if store.delete("abc") {
+		t.Error("delete(\"abc\") a second time = true, want false")
+	}
+}
+
+func TestSameContacts(t *testing.T) {
+	cases := []struct {
+		a, b []string
+		want bool
+	}{
+		{nil, nil, true},
+		{[]string{"a"}, []string{"a"}, true},
+		{[]string{"a"}, []string{"b"}, false},
+		{[]string{"a"}, []string{"a", "b"}, false},
+	}
+	for _, c := range cases {
+		if got := sameContacts(c.a, c.b); got != c.want {
+			t.Errorf("sameContacts(%v, %v) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	auth "github.com/abbot/go-http-auth"
+)
+
+// RBAC scopes understood by the admin API. The /api, /ws and /metrics routes
+// are each tagged with exactly one of these through WebProvider.withScope;
+// /health, the dashboard and /debug/vars are served without a scope check.
+const (
+	scopeConfigRead  = "config:read"
+	scopeConfigWrite = "config:write"
+	scopeAcmeRead    = "acme:read"
+	scopeAcmeWrite   = "acme:write"
+	scopeMetricsRead = "metrics:read"
+)
+
+// allScopes and readOnlyScopes back the legacy ReadOnly flag once it is
+// re-expressed as a token scope preset: full access, or read-only access.
+var (
+	allScopes      = []string{scopeConfigRead, scopeConfigWrite, scopeAcmeRead, scopeAcmeWrite, scopeMetricsRead}
+	readOnlyScopes = []string{scopeConfigRead, scopeAcmeRead, scopeMetricsRead}
+)
+
+// Auth groups the authentication backends that can be configured on the
+// admin API. Backends are tried in the order mTLS, bearer, basic; the first
+// one that authenticates the request wins.
+type Auth struct {
+	Basic  *BasicAuth  `description:"Basic auth configuration"`
+	Bearer *BearerAuth `description:"Bearer token configuration"`
+	Mtls   *MtlsAuth   `description:"Mutual TLS configuration"`
+}
+
+// BasicAuth authenticates requests against an htpasswd-style user list, be
+// it a file on disk or an inline list of "user:hashedpassword" entries.
+// A user authenticated through basic auth is granted every scope.
+type BasicAuth struct {
+	UsersFile string   `description:"Path to an htpasswd file"`
+	Users     []string `description:"Inline htpasswd-style user list"`
+
+	once          sync.Once
+	authenticator *auth.BasicAuth
+}
+
+func (basic *BasicAuth) secretProvider() auth.SecretProvider {
+	if len(basic.UsersFile) > 0 {
+		return auth.HtpasswdFileProvider(basic.UsersFile)
+	}
+	secrets := map[string]string{}
+	for _, entry := range basic.Users {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) == 2 {
+			secrets[parts[0]] = parts[1]
+		}
+	}
+	return func(user, realm string) string {
+		return secrets[user]
+	}
+}
+
+func (basic *BasicAuth) validate(request *http.Request) bool {
+	basic.once.Do(func() {
+		basic.authenticator = auth.NewBasicAuthenticator("traefik", basic.secretProvider())
+	})
+	return basic.authenticator.CheckAuth(request) != ""
+}
+
+// BearerAuth authenticates requests against a static list of bearer tokens,
+// each carrying the scopes it grants.
+type BearerAuth struct {
+	Tokens []BearerToken `description:"Accepted bearer tokens and their scopes"`
+}
+
+// BearerToken is a single accepted token and the scopes it is allowed to use.
+type BearerToken struct {
+	Token  string   `description:"Token value"`
+	Scopes []string `description:"Scopes granted to this token"`
+}
+
+func (bearer *BearerAuth) validate(request *http.Request) ([]string, bool) {
+	header := request.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return nil, false
+	}
+	token := strings.TrimPrefix(header, prefix)
+	for _, candidate := range bearer.Tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate.Token), []byte(token)) == 1 {
+			return candidate.Scopes, true
+		}
+	}
+	return nil, false
+}
+
+// MtlsAuth authenticates requests by the CN of the client certificate
+// presented during the TLS handshake, mapping it to a set of scopes.
+type MtlsAuth struct {
+	CAFile  string              `description:"CA bundle used to verify client certificates"`
+	CNRoles map[string][]string `description:"Map of certificate CN to granted scopes"`
+}
+
+func (mtls *MtlsAuth) validate(request *http.Request) ([]string, bool) {
+	if request.TLS == nil {
+		return nil, false
+	}
+	for _, cert := range request.TLS.PeerCertificates {
+		if scopes, ok := mtls.CNRoles[cert.Subject.CommonName]; ok {
+			return scopes, true
+		}
+	}
+	return nil, false
+}
+
+// authConfigured reports whether any authentication backend is set up. When
+// none is, the admin API remains reachable unauthenticated, gated only by
+// the legacy ReadOnly flag.
+func (provider *WebProvider) authConfigured() bool {
+	return provider.Auth != nil && (provider.Auth.Basic != nil || provider.Auth.Bearer != nil || provider.Auth.Mtls != nil)
+}
+
+// grantedScopes resolves the scopes a request is allowed to use. It returns
+// an error only when an auth backend is configured and the request fails to
+// authenticate against all of them.
+func (provider *WebProvider) grantedScopes(request *http.Request) ([]string, error) {
+	if !provider.authConfigured() {
+		if provider.ReadOnly {
+			return readOnlyScopes, nil
+		}
+		return allScopes, nil
+	}
+
+	if provider.Auth.Mtls != nil {
+		if scopes, ok := provider.Auth.Mtls.validate(request); ok {
+			return scopes, nil
+		}
+	}
+	if provider.Auth.Bearer != nil {
+		if scopes, ok := provider.Auth.Bearer.validate(request); ok {
+			return scopes, nil
+		}
+	}
+	if provider.Auth.Basic != nil && provider.Auth.Basic.validate(request) {
+		return allScopes, nil
+	}
+
+	return nil, fmt.Errorf("authentication required")
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, candidate := range scopes {
+		if candidate == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// withScope wraps handler so it only runs once the request has been granted
+// the given RBAC scope, replying 401 when authentication fails outright and
+// 403 when it succeeds but lacks the required scope.
+func (provider *WebProvider) withScope(scope string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		scopes, err := provider.grantedScopes(request)
+		if err != nil {
+			response.Header().Set("WWW-Authenticate", `Basic realm="traefik"`)
+			http.Error(response, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !hasScope(scopes, scope) {
+			http.Error(response, fmt.Sprintf("missing required scope %q", scope), http.StatusForbidden)
+			return
+		}
+		handler(response, request)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestHasScope(t *testing.T) {
+	scopes := []string{scopeConfigRead, scopeAcmeWrite}
+	if !hasScope(scopes, scopeConfigRead) {
+		t.Error("hasScope should find a present scope")
+	}
+	if hasScope(scopes, scopeMetricsRead) {
+		t.Error("hasScope should not find an absent scope")
+	}
+}
+
+func TestGrantedScopesNoBackendConfigured(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/api", nil)
+
+	provider := &WebProvider{}
+	scopes, err := provider.grantedScopes(request)
+	if err != nil {
+		t.Fatalf("grantedScopes: %+v", err)
+	}
+	if !hasScope(scopes, scopeConfigWrite) {
+		t.Errorf("expected full access when no auth backend is configured, got %v", scopes)
+	}
+
+	provider = &WebProvider{ReadOnly: true}
+	scopes, err = provider.grantedScopes(request)
+	if err != nil {
+		t.Fatalf("grantedScopes: %+v", err)
+	}
+	if hasScope(scopes, scopeConfigWrite) {
+		t.Errorf("ReadOnly should not grant %s, got %v", scopeConfigWrite, scopes)
+	}
+	if !hasScope(scopes, scopeConfigRead) {
+		t.Errorf("ReadOnly should still grant %s, got %v", scopeConfigRead, scopes)
+	}
+}
+
+func TestGrantedScopesBearerToken(t *testing.T) {
+	provider := &WebProvider{
+		Auth: &Auth{
+			Bearer: &BearerAuth{
+				Tokens: []BearerToken{
+					{Token: "valid-token", Scopes: []string{scopeConfigRead}},
+				},
+			},
+		},
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api", nil)
+	request.Header.Set("Authorization", "Bearer valid-token")
+	scopes, err := provider.grantedScopes(request)
+	if err != nil {
+		t.Fatalf("grantedScopes: %+v", err)
+	}
+	if !hasScope(scopes, scopeConfigRead) || hasScope(scopes, scopeConfigWrite) {
+		t.Errorf("unexpected scopes for valid bearer token: %v", scopes)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/api", nil)
+	request.Header.Set("Authorization", "Bearer wrong-token")
+	if _, err := provider.grantedScopes(request); err == nil {
+		t.Error("grantedScopes with an unknown bearer token should fail")
+	}
+}
+
+func TestGrantedScopesTriesMtlsThenBearerThenBasic(t *testing.T) {
+	provider := &WebProvider{
+		Auth: &Auth{
+			Basic: &BasicAuth{Users: []string{"admin:$apr1$dummy$invalidhash"}},
+			Bearer: &BearerAuth{
+				Tokens: []BearerToken{{Token: "bearer-token", Scopes: []string{scopeMetricsRead}}},
+			},
+			Mtls: &MtlsAuth{CNRoles: map[string][]string{}},
+		},
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api", nil)
+	request.Header.Set("Authorization", "Bearer bearer-token")
+	scopes, err := provider.grantedScopes(request)
+	if err != nil {
+		t.Fatalf("grantedScopes: %+v", err)
+	}
+	if !hasScope(scopes, scopeMetricsRead) {
+		t.Errorf("expected bearer scopes to win over basic fallback, got %v", scopes)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/api", nil)
+	if _, err := provider.grantedScopes(request); err == nil {
+		t.Error("grantedScopes with no credentials at all should fail")
+	}
+}
+
+func TestWithScopeDeniesMissingAndInsufficientScope(t *testing.T) {
+	provider := &WebProvider{ReadOnly: true}
+	called := false
+	handler := provider.withScope(scopeConfigWrite, func(http.ResponseWriter, *http.Request) {
+		called = true
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api", nil)
+	recorder := httptest.NewRecorder()
+	handler(recorder, request)
+
+	if called {
+		t.Error("handler should not run without the required scope")
+	}
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusForbidden)
+	}
+}
+
+func TestWithScopeRejectsUnauthenticated(t *testing.T) {
+	provider := &WebProvider{
+		Auth: &Auth{Bearer: &BearerAuth{Tokens: []BearerToken{{Token: "t", Scopes: allScopes}}}},
+	}
+	handler := provider.withScope(scopeConfigRead, func(http.ResponseWriter, *http.Request) {
+		t.Error("handler should not run when authentication fails")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api", nil)
+	recorder := httptest.NewRecorder()
+	handler(recorder, request)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+}
+
+// TestBasicAuthValidateRace exercises BasicAuth.validate concurrently to
+// guard against the lazy authenticator initialization racing, since
+// grantedScopes is reached from many request goroutines at once.
+func TestBasicAuthValidateRace(t *testing.T) {
+	basic := &BasicAuth{Users: []string{"admin:$apr1$dummy$invalidhash"}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := httptest.NewRequest(http.MethodGet, "/api", nil)
+			basic.validate(request)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/negroni"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultMetricsBuckets are the histogram buckets (in seconds) used for the
+// request-duration metric when the operator does not configure its own.
+var defaultMetricsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	reqCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "traefik_requests_total",
+		Help: "How many HTTP requests processed, partitioned by frontend, backend, status code and method.",
+	}, []string{"frontend", "backend", "code", "method"})
+
+	reqDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "traefik_request_duration_seconds",
+		Help:    "Request duration in seconds, partitioned by frontend, backend, status code and method.",
+		Buckets: defaultMetricsBuckets,
+	}, []string{"frontend", "backend", "code", "method"})
+
+	reqsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "traefik_requests_in_flight",
+		Help: "How many HTTP requests are currently being served, partitioned by frontend and backend.",
+	}, []string{"frontend", "backend"})
+
+	openConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "traefik_entrypoint_open_connections",
+		Help: "How many connections are currently open, partitioned by entry point.",
+	}, []string{"entrypoint"})
+)
+
+func init() {
+	prometheus.MustRegister(reqCounter, reqDurationHistogram, reqsInFlight, openConnections)
+}
+
+// configureMetricsBuckets swaps in operator-provided histogram buckets for
+// the request-duration metric. It must be called before any request is
+// served, since Prometheus collectors cannot change their bucket layout
+// once a sample has been observed.
+func configureMetricsBuckets(buckets []float64) {
+	if len(buckets) == 0 {
+		return
+	}
+	prometheus.Unregister(reqDurationHistogram)
+	reqDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "traefik_request_duration_seconds",
+		Help:    "Request duration in seconds, partitioned by frontend, backend, status code and method.",
+		Buckets: buckets,
+	}, []string{"frontend", "backend", "code", "method"})
+	prometheus.MustRegister(reqDurationHistogram)
+}
+
+// recordOpenConnection lets an entry point listener report how many
+// connections it currently holds open.
+func recordOpenConnection(entryPointName string, delta float64) {
+	openConnections.WithLabelValues(entryPointName).Add(delta)
+}
+
+// WrapFrontend wraps next with a MetricsMiddleware labelling its metrics
+// with frontendName/backendName. Provide uses it to label the admin API's
+// own router; proxied frontends are not wrapped, so traefik_requests_total
+// and friends count admin API traffic only.
+func WrapFrontend(next http.Handler, frontendName, backendName string) http.Handler {
+	return negroni.New(NewMetricsMiddleware(frontendName, backendName), negroni.Wrap(next))
+}
+
+// MetricsMiddleware is a negroni-compatible handler that records Prometheus
+// metrics for every request going through a given frontend/backend pair.
+type MetricsMiddleware struct {
+	frontendName string
+	backendName  string
+}
+
+// NewMetricsMiddleware returns a MetricsMiddleware labelling its metrics with
+// the given frontend and backend names.
+func NewMetricsMiddleware(frontendName, backendName string) *MetricsMiddleware {
+	return &MetricsMiddleware{
+		frontendName: frontendName,
+		backendName:  backendName,
+	}
+}
+
+func (m *MetricsMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	reqsInFlight.WithLabelValues(m.frontendName, m.backendName).Inc()
+	defer reqsInFlight.WithLabelValues(m.frontendName, m.backendName).Dec()
+
+	start := time.Now()
+	recorder := &metricsStatusRecorder{ResponseWriter: rw, statusCode: http.StatusOK}
+	next(recorder, r)
+
+	labels := prometheus.Labels{
+		"frontend": m.frontendName,
+		"backend":  m.backendName,
+		"code":     strconv.Itoa(recorder.statusCode),
+		"method":   r.Method,
+	}
+	reqCounter.With(labels).Inc()
+	reqDurationHistogram.With(labels).Observe(time.Since(start).Seconds())
+}
+
+// metricsStatusRecorder captures the status code written by the wrapped
+// handler so it can be used as a metric label.
+type metricsStatusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *metricsStatusRecorder) WriteHeader(code int) {
+	r.statusCode = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Flush and Hijack pass through to the wrapped writer so /api/stream and /ws
+// keep working behind the middleware.
+func (r *metricsStatusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *metricsStatusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := r.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+var _ negroni.Handler = (*MetricsMiddleware)(nil)
+
+// countingListener wraps a net.Listener so every accepted connection updates
+// the open-connections gauge for the given entry point, decrementing it
+// again exactly once when the connection is closed.
+type countingListener struct {
+	net.Listener
+	entryPointName string
+}
+
+// newCountingListener returns a net.Listener that reports its accepted
+// connections to the traefik_entrypoint_open_connections gauge.
+func newCountingListener(inner net.Listener, entryPointName string) net.Listener {
+	return &countingListener{Listener: inner, entryPointName: entryPointName}
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	recordOpenConnection(l.entryPointName, 1)
+	return &countingConn{Conn: conn, entryPointName: l.entryPointName}, nil
+}
+
+// countingConn decrements the open-connections gauge on Close, guarding
+// against the gauge being decremented twice if Close is called more than
+// once, which net.Conn permits.
+type countingConn struct {
+	net.Conn
+	entryPointName string
+	closeOnce      sync.Once
+}
+
+func (c *countingConn) Close() error {
+	c.closeOnce.Do(func() {
+		recordOpenConnection(c.entryPointName, -1)
+	})
+	return c.Conn.Close()
+}
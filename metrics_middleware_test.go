@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMetricsMiddlewareRecordsLabels(t *testing.T) {
+	middleware := NewMetricsMiddleware("my-frontend", "my-backend")
+
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	middleware.ServeHTTP(recorder, request, next)
+
+	labels := prometheus.Labels{
+		"frontend": "my-frontend",
+		"backend":  "my-backend",
+		"code":     "418",
+		"method":   "GET",
+	}
+	if got := testutilCounterValue(t, reqCounter, labels); got != 1 {
+		t.Errorf("traefik_requests_total%v = %v, want 1", labels, got)
+	}
+}
+
+func TestWrapFrontendInstallsMiddleware(t *testing.T) {
+	inner := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	wrapped := WrapFrontend(inner, "wrapped-frontend", "wrapped-backend")
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	wrapped.ServeHTTP(recorder, request)
+
+	labels := prometheus.Labels{
+		"frontend": "wrapped-frontend",
+		"backend":  "wrapped-backend",
+		"code":     "200",
+		"method":   "GET",
+	}
+	if got := testutilCounterValue(t, reqCounter, labels); got != 1 {
+		t.Errorf("traefik_requests_total%v = %v, want 1", labels, got)
+	}
+}
+
+func TestCountingListenerTracksOpenConnections(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %+v", err)
+	}
+	defer inner.Close()
+
+	const entryPointName = "test-entrypoint"
+	listener := newCountingListener(inner, entryPointName)
+
+	dialed, err := net.Dial("tcp", inner.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial: %+v", err)
+	}
+	defer dialed.Close()
+
+	accepted, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %+v", err)
+	}
+
+	if got := testutilGaugeValue(t, openConnections, prometheus.Labels{"entrypoint": entryPointName}); got != 1 {
+		t.Fatalf("open connections after accept = %v, want 1", got)
+	}
+
+	accepted.Close()
+	accepted.Close() // closing twice must only decrement the gauge once
+
+	if got := testutilGaugeValue(t, openConnections, prometheus.Labels{"entrypoint": entryPointName}); got != 0 {
+		t.Fatalf("open connections after close = %v, want 0", got)
+	}
+}
+
+func testutilCounterValue(t *testing.T, vec *prometheus.CounterVec, labels prometheus.Labels) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := vec.With(labels).Write(&metric); err != nil {
+		t.Fatalf("Write: %+v", err)
+	}
+	return metric.Counter.GetValue()
+}
+
+func testutilGaugeValue(t *testing.T, vec *prometheus.GaugeVec, labels prometheus.Labels) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := vec.With(labels).Write(&metric); err != nil {
+		t.Fatalf("Write: %+v", err)
+	}
+	return metric.Gauge.GetValue()
+}
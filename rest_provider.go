@@ -0,0 +1,452 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/containous/traefik/types"
+	evanjsonpatch "github.com/evanphx/json-patch"
+	"github.com/gorilla/mux"
+)
+
+// restProviderName is the provider key the targeted write API reads from
+// and publishes to, mirroring how the legacy whole-configuration PUT always
+// targets the "web" provider.
+const restProviderName = "rest"
+
+// restConfiguration returns the provider's current view of the "rest"
+// configuration, creating an empty one if nothing has been published yet.
+func (provider *WebProvider) restConfiguration() *types.Configuration {
+	currentConfigurations := provider.server.currentConfigurations.Get().(configs)
+	if config, ok := currentConfigurations[restProviderName]; ok {
+		return config
+	}
+	return &types.Configuration{
+		Backends:  map[string]*types.Backend{},
+		Frontends: map[string]*types.Frontend{},
+	}
+}
+
+// cloneRestConfiguration deep-copies config via a JSON round-trip so
+// sub-resource handlers can mutate the copy in place without racing the
+// read handlers or the router against the live *types.Configuration held
+// in currentConfigurations.
+func cloneRestConfiguration(config *types.Configuration) (*types.Configuration, error) {
+	payload, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	clone := new(types.Configuration)
+	if err := json.Unmarshal(payload, clone); err != nil {
+		return nil, err
+	}
+	if clone.Backends == nil {
+		clone.Backends = map[string]*types.Backend{}
+	}
+	if clone.Frontends == nil {
+		clone.Frontends = map[string]*types.Frontend{}
+	}
+	return clone, nil
+}
+
+// restConfigurationETag derives an optimistic-concurrency token from a hash
+// of the configuration's JSON representation.
+func restConfigurationETag(config *types.Configuration) string {
+	payload, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// validateRestConfiguration rejects a configuration with an unknown
+// load-balancer method, a server without a url, a frontend referencing an
+// unknown backend, or a route with an empty rule. Rule syntax is not parsed.
+func validateRestConfiguration(config *types.Configuration) error {
+	for backendName, backend := range config.Backends {
+		if backend.LoadBalancer != nil {
+			switch backend.LoadBalancer.Method {
+			case "", "wrr", "drr":
+			default:
+				return fmt.Errorf("backend %q: unknown load-balancer method %q", backendName, backend.LoadBalancer.Method)
+			}
+		}
+		for serverName, server := range backend.Servers {
+			if server.URL == "" {
+				return fmt.Errorf("backend %q: server %q is missing a url", backendName, serverName)
+			}
+		}
+	}
+	for frontendName, frontend := range config.Frontends {
+		if _, ok := config.Backends[frontend.Backend]; !ok {
+			return fmt.Errorf("frontend %q: references unknown backend %q", frontendName, frontend.Backend)
+		}
+		for routeName, route := range frontend.Routes {
+			if route.Rule == "" {
+				return fmt.Errorf("frontend %q: route %q has an empty rule", frontendName, routeName)
+			}
+		}
+	}
+	return nil
+}
+
+// checkIfMatch enforces the If-Match header against the ETag of config,
+// writing a 409 and returning false when the header is present and stale.
+func checkIfMatch(response http.ResponseWriter, request *http.Request, config *types.Configuration) bool {
+	ifMatch := request.Header.Get("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+	if ifMatch != restConfigurationETag(config) {
+		http.Error(response, "configuration has changed since this ETag was issued", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// publishRestConfiguration validates config, publishes it to the "rest"
+// provider, feeds configStream directly so subscribers see the change
+// without waiting on a poll, and replies with the new configuration and its
+// fresh ETag.
+func publishRestConfiguration(response http.ResponseWriter, configurationChan chan<- types.ConfigMessage, config *types.Configuration) {
+	if err := validateRestConfiguration(config); err != nil {
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+	configurationChan <- types.ConfigMessage{restProviderName, config}
+	configStream.publish(restProviderName, config)
+	response.Header().Set("ETag", restConfigurationETag(config))
+	templatesRenderer.JSON(response, http.StatusOK, config)
+}
+
+// patchRestProviderHandler serves PATCH /api/providers/rest, applying
+// either a JSON Patch (RFC 6902, Content-Type application/json-patch+json)
+// or a JSON Merge Patch (RFC 7396, application/merge-patch+json, the
+// default) to the current "rest" configuration.
+func (provider *WebProvider) patchRestProviderHandler(configurationChan chan<- types.ConfigMessage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if provider.ReadOnly {
+			http.Error(response, "REST API is in read-only mode", http.StatusForbidden)
+			return
+		}
+
+		current := provider.restConfiguration()
+		if !checkIfMatch(response, request, current) {
+			return
+		}
+
+		currentJSON, err := json.Marshal(current)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+			return
+		}
+
+		body, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+			return
+		}
+
+		var patched []byte
+		switch request.Header.Get("Content-Type") {
+		case "application/json-patch+json":
+			patch, err := evanjsonpatch.DecodePatch(body)
+			if err != nil {
+				http.Error(response, fmt.Sprintf("invalid JSON Patch: %+v", err), http.StatusBadRequest)
+				return
+			}
+			patched, err = patch.Apply(currentJSON)
+			if err != nil {
+				http.Error(response, fmt.Sprintf("error applying JSON Patch: %+v", err), http.StatusBadRequest)
+				return
+			}
+		case "application/merge-patch+json", "":
+			patched, err = evanjsonpatch.MergePatch(currentJSON, body)
+			if err != nil {
+				http.Error(response, fmt.Sprintf("error applying JSON Merge Patch: %+v", err), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(response, "unsupported Content-Type, expected application/json-patch+json or application/merge-patch+json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		updated := new(types.Configuration)
+		if err := json.Unmarshal(patched, updated); err != nil {
+			log.Errorf("Error parsing patched configuration %+v", err)
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+			return
+		}
+		publishRestConfiguration(response, configurationChan, updated)
+	}
+}
+
+// putRestBackendHandler serves PUT /api/providers/rest/backends/{backend}.
+func (provider *WebProvider) putRestBackendHandler(configurationChan chan<- types.ConfigMessage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if provider.ReadOnly {
+			http.Error(response, "REST API is in read-only mode", http.StatusForbidden)
+			return
+		}
+
+		live := provider.restConfiguration()
+		if !checkIfMatch(response, request, live) {
+			return
+		}
+		current, err := cloneRestConfiguration(live)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+			return
+		}
+
+		backend := new(types.Backend)
+		body, _ := ioutil.ReadAll(request.Body)
+		if err := json.Unmarshal(body, backend); err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+			return
+		}
+
+		current.Backends[mux.Vars(request)["backend"]] = backend
+		publishRestConfiguration(response, configurationChan, current)
+	}
+}
+
+// deleteRestBackendHandler serves DELETE /api/providers/rest/backends/{backend}.
+func (provider *WebProvider) deleteRestBackendHandler(configurationChan chan<- types.ConfigMessage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if provider.ReadOnly {
+			http.Error(response, "REST API is in read-only mode", http.StatusForbidden)
+			return
+		}
+
+		live := provider.restConfiguration()
+		if !checkIfMatch(response, request, live) {
+			return
+		}
+
+		backendName := mux.Vars(request)["backend"]
+		if _, ok := live.Backends[backendName]; !ok {
+			http.NotFound(response, request)
+			return
+		}
+		current, err := cloneRestConfiguration(live)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+			return
+		}
+		delete(current.Backends, backendName)
+		publishRestConfiguration(response, configurationChan, current)
+	}
+}
+
+// putRestServerHandler serves PUT /api/providers/rest/backends/{backend}/servers/{server}.
+func (provider *WebProvider) putRestServerHandler(configurationChan chan<- types.ConfigMessage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if provider.ReadOnly {
+			http.Error(response, "REST API is in read-only mode", http.StatusForbidden)
+			return
+		}
+
+		live := provider.restConfiguration()
+		if !checkIfMatch(response, request, live) {
+			return
+		}
+
+		vars := mux.Vars(request)
+		if _, ok := live.Backends[vars["backend"]]; !ok {
+			http.NotFound(response, request)
+			return
+		}
+
+		server := new(types.Server)
+		body, _ := ioutil.ReadAll(request.Body)
+		if err := json.Unmarshal(body, server); err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+			return
+		}
+
+		current, err := cloneRestConfiguration(live)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+			return
+		}
+		backend := current.Backends[vars["backend"]]
+		if backend.Servers == nil {
+			backend.Servers = map[string]types.Server{}
+		}
+		backend.Servers[vars["server"]] = *server
+		publishRestConfiguration(response, configurationChan, current)
+	}
+}
+
+// deleteRestServerHandler serves DELETE /api/providers/rest/backends/{backend}/servers/{server}.
+func (provider *WebProvider) deleteRestServerHandler(configurationChan chan<- types.ConfigMessage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if provider.ReadOnly {
+			http.Error(response, "REST API is in read-only mode", http.StatusForbidden)
+			return
+		}
+
+		live := provider.restConfiguration()
+		if !checkIfMatch(response, request, live) {
+			return
+		}
+
+		vars := mux.Vars(request)
+		liveBackend, ok := live.Backends[vars["backend"]]
+		if !ok {
+			http.NotFound(response, request)
+			return
+		}
+		if _, ok := liveBackend.Servers[vars["server"]]; !ok {
+			http.NotFound(response, request)
+			return
+		}
+
+		current, err := cloneRestConfiguration(live)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+			return
+		}
+		delete(current.Backends[vars["backend"]].Servers, vars["server"])
+		publishRestConfiguration(response, configurationChan, current)
+	}
+}
+
+// putRestFrontendHandler serves PUT /api/providers/rest/frontends/{frontend}.
+func (provider *WebProvider) putRestFrontendHandler(configurationChan chan<- types.ConfigMessage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if provider.ReadOnly {
+			http.Error(response, "REST API is in read-only mode", http.StatusForbidden)
+			return
+		}
+
+		live := provider.restConfiguration()
+		if !checkIfMatch(response, request, live) {
+			return
+		}
+		current, err := cloneRestConfiguration(live)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+			return
+		}
+
+		frontend := new(types.Frontend)
+		body, _ := ioutil.ReadAll(request.Body)
+		if err := json.Unmarshal(body, frontend); err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+			return
+		}
+
+		current.Frontends[mux.Vars(request)["frontend"]] = frontend
+		publishRestConfiguration(response, configurationChan, current)
+	}
+}
+
+// deleteRestFrontendHandler serves DELETE /api/providers/rest/frontends/{frontend}.
+func (provider *WebProvider) deleteRestFrontendHandler(configurationChan chan<- types.ConfigMessage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if provider.ReadOnly {
+			http.Error(response, "REST API is in read-only mode", http.StatusForbidden)
+			return
+		}
+
+		live := provider.restConfiguration()
+		if !checkIfMatch(response, request, live) {
+			return
+		}
+
+		frontendName := mux.Vars(request)["frontend"]
+		if _, ok := live.Frontends[frontendName]; !ok {
+			http.NotFound(response, request)
+			return
+		}
+		current, err := cloneRestConfiguration(live)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+			return
+		}
+		delete(current.Frontends, frontendName)
+		publishRestConfiguration(response, configurationChan, current)
+	}
+}
+
+// putRestRouteHandler serves PUT /api/providers/rest/frontends/{frontend}/routes/{route}.
+func (provider *WebProvider) putRestRouteHandler(configurationChan chan<- types.ConfigMessage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if provider.ReadOnly {
+			http.Error(response, "REST API is in read-only mode", http.StatusForbidden)
+			return
+		}
+
+		live := provider.restConfiguration()
+		if !checkIfMatch(response, request, live) {
+			return
+		}
+
+		vars := mux.Vars(request)
+		if _, ok := live.Frontends[vars["frontend"]]; !ok {
+			http.NotFound(response, request)
+			return
+		}
+
+		route := new(types.Route)
+		body, _ := ioutil.ReadAll(request.Body)
+		if err := json.Unmarshal(body, route); err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+			return
+		}
+
+		current, err := cloneRestConfiguration(live)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+			return
+		}
+		frontend := current.Frontends[vars["frontend"]]
+		if frontend.Routes == nil {
+			frontend.Routes = map[string]types.Route{}
+		}
+		frontend.Routes[vars["route"]] = *route
+		publishRestConfiguration(response, configurationChan, current)
+	}
+}
+
+// deleteRestRouteHandler serves DELETE /api/providers/rest/frontends/{frontend}/routes/{route}.
+func (provider *WebProvider) deleteRestRouteHandler(configurationChan chan<- types.ConfigMessage) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		if provider.ReadOnly {
+			http.Error(response, "REST API is in read-only mode", http.StatusForbidden)
+			return
+		}
+
+		live := provider.restConfiguration()
+		if !checkIfMatch(response, request, live) {
+			return
+		}
+
+		vars := mux.Vars(request)
+		liveFrontend, ok := live.Frontends[vars["frontend"]]
+		if !ok {
+			http.NotFound(response, request)
+			return
+		}
+		if _, ok := liveFrontend.Routes[vars["route"]]; !ok {
+			http.NotFound(response, request)
+			return
+		}
+
+		current, err := cloneRestConfiguration(live)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+			return
+		}
+		delete(current.Frontends[vars["frontend"]].Routes, vars["route"])
+		publishRestConfiguration(response, configurationChan, current)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+)
+
+func sampleRestConfiguration() *types.Configuration {
+	return &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"backend1": {
+				Servers: map[string]types.Server{
+					"server1": {URL: "http://127.0.0.1:8080"},
+				},
+			},
+		},
+		Frontends: map[string]*types.Frontend{
+			"frontend1": {
+				Backend: "backend1",
+				Routes: map[string]types.Route{
+					"route1": {Rule: "Host:example.com"},
+				},
+			},
+		},
+	}
+}
+
+func TestRestConfigurationETagIsStableAndChanges(t *testing.T) {
+	config := sampleRestConfiguration()
+	etag := restConfigurationETag(config)
+	if etag == "" {
+		t.Fatal("restConfigurationETag returned an empty string")
+	}
+	if restConfigurationETag(config) != etag {
+		t.Error("restConfigurationETag should be stable for an unchanged configuration")
+	}
+
+	config.Backends["backend2"] = &types.Backend{}
+	if restConfigurationETag(config) == etag {
+		t.Error("restConfigurationETag should change when the configuration changes")
+	}
+}
+
+func TestCloneRestConfigurationIsIndependent(t *testing.T) {
+	original := sampleRestConfiguration()
+	clone, err := cloneRestConfiguration(original)
+	if err != nil {
+		t.Fatalf("cloneRestConfiguration: %+v", err)
+	}
+
+	clone.Backends["backend1"].Servers["server1"] = types.Server{URL: "http://mutated"}
+	if original.Backends["backend1"].Servers["server1"].URL == "http://mutated" {
+		t.Error("mutating the clone should not affect the original configuration")
+	}
+
+	delete(clone.Frontends, "frontend1")
+	if _, ok := original.Frontends["frontend1"]; !ok {
+		t.Error("deleting from the clone should not affect the original configuration")
+	}
+}
+
+func TestCloneRestConfigurationInitializesNilMaps(t *testing.T) {
+	clone, err := cloneRestConfiguration(&types.Configuration{})
+	if err != nil {
+		t.Fatalf("cloneRestConfiguration: %+v", err)
+	}
+	if clone.Backends == nil {
+		t.Error("cloneRestConfiguration should initialize a nil Backends map")
+	}
+	if clone.Frontends == nil {
+		t.Error("cloneRestConfiguration should initialize a nil Frontends map")
+	}
+}
+
+func TestValidateRestConfiguration(t *testing.T) {
+	if err := validateRestConfiguration(sampleRestConfiguration()); err != nil {
+		t.Errorf("validateRestConfiguration(valid config) = %+v, want nil", err)
+	}
+
+	missingServerURL := sampleRestConfiguration()
+	missingServerURL.Backends["backend1"].Servers["server1"] = types.Server{}
+	if err := validateRestConfiguration(missingServerURL); err == nil {
+		t.Error("validateRestConfiguration should reject a server with no url")
+	}
+
+	unknownBackend := sampleRestConfiguration()
+	unknownBackend.Frontends["frontend1"].Backend = "does-not-exist"
+	if err := validateRestConfiguration(unknownBackend); err == nil {
+		t.Error("validateRestConfiguration should reject a frontend referencing an unknown backend")
+	}
+
+	emptyRule := sampleRestConfiguration()
+	emptyRule.Frontends["frontend1"].Routes["route1"] = types.Route{Rule: ""}
+	if err := validateRestConfiguration(emptyRule); err == nil {
+		t.Error("validateRestConfiguration should reject a route with an empty rule")
+	}
+
+	badLoadBalancer := sampleRestConfiguration()
+	badLoadBalancer.Backends["backend1"].LoadBalancer = &types.LoadBalancer{Method: "bogus"}
+	if err := validateRestConfiguration(badLoadBalancer); err == nil {
+		t.Error("validateRestConfiguration should reject an unknown load-balancer method")
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	config := sampleRestConfiguration()
+	etag := restConfigurationETag(config)
+
+	request := httptest.NewRequest(http.MethodPut, "/api/providers/rest/backends/backend1", nil)
+	recorder := httptest.NewRecorder()
+	if !checkIfMatch(recorder, request, config) {
+		t.Error("checkIfMatch without an If-Match header should pass")
+	}
+
+	request = httptest.NewRequest(http.MethodPut, "/api/providers/rest/backends/backend1", nil)
+	request.Header.Set("If-Match", etag)
+	recorder = httptest.NewRecorder()
+	if !checkIfMatch(recorder, request, config) {
+		t.Error("checkIfMatch with a matching If-Match header should pass")
+	}
+
+	request = httptest.NewRequest(http.MethodPut, "/api/providers/rest/backends/backend1", nil)
+	request.Header.Set("If-Match", "stale-etag")
+	recorder = httptest.NewRecorder()
+	if checkIfMatch(recorder, request, config) {
+		t.Error("checkIfMatch with a stale If-Match header should fail")
+	}
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusConflict)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gorilla/websocket"
+	jsonpatch "github.com/mattbaird/jsonpatch"
+)
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+	streamBacklogLimit      = 256
+	streamHistoryLimit      = 1024
+)
+
+// configEvent is a single message pushed to /api/stream and /ws subscribers.
+type configEvent struct {
+	id       int64
+	Type     string                        `json:"type"`
+	Provider string                        `json:"provider,omitempty"`
+	Data     interface{}                   `json:"data,omitempty"`
+	Diff     []jsonpatch.JsonPatchOperation `json:"diff,omitempty"`
+}
+
+// configBroadcaster fans out configuration changes to every connected
+// stream subscriber. It is fed by the "web" and "rest" provider handlers
+// only (see configStream); changes from other providers reaching
+// server.currentConfigurations are not published to it. It keeps enough
+// history to let a reconnecting client resume from a Last-Event-ID.
+type configBroadcaster struct {
+	mu             sync.Mutex
+	nextID         int64
+	subscribers    map[chan configEvent]struct{}
+	lastByProvider map[string][]byte
+	history        []configEvent
+}
+
+func newConfigBroadcaster() *configBroadcaster {
+	return &configBroadcaster{
+		subscribers:    map[chan configEvent]struct{}{},
+		lastByProvider: map[string][]byte{},
+	}
+}
+
+// configStream is the single broadcaster backing both /api/stream and /ws;
+// the "web" and "rest" provider handlers publish to it as soon as they hand
+// a new configuration to configurationChan.
+var configStream = newConfigBroadcaster()
+
+// publish diffs newConfig for providerName against the last configuration
+// broadcast for that provider and fans out a snapshot or patch event.
+func (b *configBroadcaster) publish(providerName string, newConfig interface{}) {
+	payload, err := json.Marshal(newConfig)
+	if err != nil {
+		log.Errorf("Error marshalling configuration for streaming: %+v", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	previous, hasPrevious := b.lastByProvider[providerName]
+	if hasPrevious && bytes.Equal(previous, payload) {
+		return
+	}
+	b.lastByProvider[providerName] = payload
+	b.nextID++
+
+	event := configEvent{id: b.nextID, Provider: providerName}
+	if !hasPrevious {
+		event.Type = "snapshot"
+		event.Data = newConfig
+	} else {
+		diff, err := jsonpatch.CreatePatch(previous, payload)
+		if err != nil {
+			log.Errorf("Error creating patch for streaming: %+v", err)
+			return
+		}
+		event.Type = "patch"
+		event.Diff = diff
+	}
+
+	b.history = append(b.history, event)
+	if len(b.history) > streamHistoryLimit {
+		b.history = b.history[len(b.history)-streamHistoryLimit:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Warnf("Dropping slow configuration stream subscriber, backlog exceeded %d events", streamBacklogLimit)
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe registers a new consumer and returns the backlog of events it
+// missed since afterID, a live channel to keep reading from, and an
+// unsubscribe func the caller must defer. A fresh subscriber (afterID 0)
+// always gets a snapshot of every provider's current configuration built
+// straight from lastByProvider, rather than replaying history, since the
+// original snapshot event for a long-lived provider may have already been
+// evicted by streamHistoryLimit. A reconnecting subscriber (afterID > 0)
+// replays history instead, since it already has a base to patch against.
+func (b *configBroadcaster) subscribe(afterID int64) ([]configEvent, chan configEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []configEvent
+	if afterID == 0 {
+		for providerName, payload := range b.lastByProvider {
+			backlog = append(backlog, configEvent{
+				id:       b.nextID,
+				Type:     "snapshot",
+				Provider: providerName,
+				Data:     json.RawMessage(payload),
+			})
+		}
+	} else {
+		for _, event := range b.history {
+			if event.id > afterID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	ch := make(chan configEvent, streamBacklogLimit)
+	b.subscribers[ch] = struct{}{}
+
+	return backlog, ch, func() {
+		b.mu.Lock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+		b.mu.Unlock()
+	}
+}
+
+func writeSSEEvent(response http.ResponseWriter, event configEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(response, "id: %d\ndata: %s\n\n", event.id, payload); err != nil {
+		return err
+	}
+	return nil
+}
+
+// streamHandler serves GET /api/stream: a Server-Sent Events feed of the
+// configuration snapshot followed by live patches. Last-Event-ID is honored
+// to replay events missed while disconnected.
+func (provider *WebProvider) streamHandler(response http.ResponseWriter, request *http.Request) {
+	flusher, ok := response.(http.Flusher)
+	if !ok {
+		http.Error(response, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var afterID int64
+	if lastEventID := request.Header.Get("Last-Event-ID"); lastEventID != "" {
+		afterID, _ = strconv.ParseInt(lastEventID, 10, 64)
+	}
+
+	backlog, events, unsubscribe := configStream.subscribe(afterID)
+	defer unsubscribe()
+
+	response.Header().Set("Content-Type", "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.Header().Set("Connection", "keep-alive")
+
+	for _, event := range backlog {
+		if err := writeSSEEvent(response, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			if err := writeSSEEvent(response, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprintf(response, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-request.Context().Done():
+			return
+		}
+	}
+}
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// wsHandler serves GET /ws: the WebSocket equivalent of streamHandler,
+// pushing the same snapshot/patch events as JSON text frames.
+func (provider *WebProvider) wsHandler(response http.ResponseWriter, request *http.Request) {
+	conn, err := streamUpgrader.Upgrade(response, request, nil)
+	if err != nil {
+		log.Errorf("Error upgrading to websocket: %+v", err)
+		return
+	}
+	defer conn.Close()
+
+	backlog, events, unsubscribe := configStream.subscribe(0)
+	defer unsubscribe()
+
+	for _, event := range backlog {
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+)
+
+type streamBackend struct {
+	Name string `json:"name"`
+}
+
+func TestConfigBroadcasterPublishSendsSnapshotThenPatch(t *testing.T) {
+	broadcaster := newConfigBroadcaster()
+	_, events, unsubscribe := broadcaster.subscribe(0)
+	defer unsubscribe()
+
+	broadcaster.publish("rest", &streamBackend{Name: "a"})
+	first := <-events
+	if first.Type != "snapshot" {
+		t.Fatalf("first event type = %q, want %q", first.Type, "snapshot")
+	}
+	if first.Provider != "rest" {
+		t.Fatalf("first event provider = %q, want %q", first.Provider, "rest")
+	}
+
+	broadcaster.publish("rest", &streamBackend{Name: "b"})
+	second := <-events
+	if second.Type != "patch" {
+		t.Fatalf("second event type = %q, want %q", second.Type, "patch")
+	}
+	if len(second.Diff) == 0 {
+		t.Fatal("second event should carry a non-empty JSON patch diff")
+	}
+}
+
+func TestConfigBroadcasterPublishDedupesNoOpUpdates(t *testing.T) {
+	broadcaster := newConfigBroadcaster()
+	_, events, unsubscribe := broadcaster.subscribe(0)
+	defer unsubscribe()
+
+	broadcaster.publish("rest", &streamBackend{Name: "a"})
+	<-events
+
+	broadcaster.publish("rest", &streamBackend{Name: "a"})
+
+	select {
+	case event := <-events:
+		t.Fatalf("publishing an unchanged configuration should not emit an event, got %+v", event)
+	default:
+	}
+}
+
+func TestConfigBroadcasterSubscribeFreshGetsSnapshotEvenAfterHistoryEviction(t *testing.T) {
+	broadcaster := newConfigBroadcaster()
+
+	broadcaster.publish("rest", &streamBackend{Name: "initial"})
+	for i := 0; i < streamHistoryLimit+10; i++ {
+		broadcaster.publish("rest", &streamBackend{Name: string(rune('a' + i%26))})
+	}
+
+	backlog, _, unsubscribe := broadcaster.subscribe(0)
+	defer unsubscribe()
+
+	if len(backlog) != 1 {
+		t.Fatalf("fresh subscribe backlog = %d events, want 1 synthesized snapshot", len(backlog))
+	}
+	if backlog[0].Type != "snapshot" {
+		t.Fatalf("fresh subscribe backlog[0].Type = %q, want %q", backlog[0].Type, "snapshot")
+	}
+	if backlog[0].Provider != "rest" {
+		t.Fatalf("fresh subscribe backlog[0].Provider = %q, want %q", backlog[0].Provider, "rest")
+	}
+}
+
+func TestConfigBroadcasterSubscribeReconnectReplaysHistory(t *testing.T) {
+	broadcaster := newConfigBroadcaster()
+
+	broadcaster.publish("rest", &streamBackend{Name: "a"})
+	firstBacklog, _, unsubscribe := broadcaster.subscribe(0)
+	unsubscribe()
+	lastSeenID := firstBacklog[0].id
+
+	broadcaster.publish("rest", &streamBackend{Name: "b"})
+	broadcaster.publish("rest", &streamBackend{Name: "c"})
+
+	backlog, _, unsubscribe := broadcaster.subscribe(lastSeenID)
+	defer unsubscribe()
+
+	if len(backlog) != 2 {
+		t.Fatalf("reconnect backlog = %d events, want 2", len(backlog))
+	}
+	for _, event := range backlog {
+		if event.Type != "patch" {
+			t.Errorf("reconnect backlog event type = %q, want %q", event.Type, "patch")
+		}
+	}
+}
+
+func TestConfigBroadcasterUnsubscribeStopsDelivery(t *testing.T) {
+	broadcaster := newConfigBroadcaster()
+	_, events, unsubscribe := broadcaster.subscribe(0)
+	unsubscribe()
+
+	broadcaster.publish("rest", &streamBackend{Name: "a"})
+
+	if _, open := <-events; open {
+		t.Error("events channel should be closed after unsubscribe")
+	}
+}
@@ -1,12 +1,24 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"expvar"
 	"fmt"
 	"io/ioutil"
+	"math/big"
+	"net"
 	"net/http"
 	"runtime"
+	"sync"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/containous/traefik/autogen"
@@ -14,6 +26,8 @@ import (
 	"github.com/containous/traefik/types"
 	"github.com/elazarl/go-bindata-assetfs"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/satori/go.uuid"
 	"github.com/thoas/stats"
 	"github.com/unrolled/render"
 )
@@ -23,13 +37,28 @@ var metrics = stats.New()
 // WebProvider is a provider.Provider implementation that provides the UI.
 // FIXME to be handled another way.
 type WebProvider struct {
-	Address  string `description:"Web administration port"`
-	CertFile string `description:"SSL certificate"`
-	KeyFile  string `description:"SSL certificate"`
-	ReadOnly bool   `description:"Enable read only API"`
+	Address  string   `description:"Web administration port"`
+	CertFile string   `description:"SSL certificate"`
+	KeyFile  string   `description:"SSL certificate"`
+	ReadOnly bool     `description:"Enable read only API"`
+	Metrics  *Metrics `description:"Metrics exporters configuration"`
+	Auth     *Auth    `description:"Authentication configuration"`
 	server   *Server
 }
 
+// Metrics groups the metrics exporters traefik can expose on the admin API.
+type Metrics struct {
+	Prometheus *Prometheus `description:"Prometheus metrics exporter"`
+}
+
+// Prometheus configures the /metrics endpoint exposing traefik's internal
+// metrics in the Prometheus text exposition format.
+type Prometheus struct {
+	Enabled    bool      `description:"Enable the Prometheus metrics exporter"`
+	Buckets    []float64 `description:"Buckets for latency metrics"`
+	EntryPoint string    `description:"Listen address (host:port) of a separate listener to serve the metrics on; empty or equal to Address serves them on the admin API"`
+}
+
 var (
 	templatesRenderer = render.New(render.Options{
 		Directory: "nowhere",
@@ -53,10 +82,10 @@ func (provider *WebProvider) Provide(configurationChan chan<- types.ConfigMessag
 	systemRouter.Methods("GET").Path("/health").HandlerFunc(provider.getHealthHandler)
 
 	// API routes
-	systemRouter.Methods("GET").Path("/api").HandlerFunc(provider.getConfigHandler)
-	systemRouter.Methods("GET").Path("/api/providers").HandlerFunc(provider.getConfigHandler)
-	systemRouter.Methods("GET").Path("/api/providers/{provider}").HandlerFunc(provider.getProviderHandler)
-	systemRouter.Methods("PUT").Path("/api/providers/{provider}").HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+	systemRouter.Methods("GET").Path("/api").HandlerFunc(provider.withScope(scopeConfigRead, provider.getConfigHandler))
+	systemRouter.Methods("GET").Path("/api/providers").HandlerFunc(provider.withScope(scopeConfigRead, provider.getConfigHandler))
+	systemRouter.Methods("GET").Path("/api/providers/{provider}").HandlerFunc(provider.withScope(scopeConfigRead, provider.getProviderHandler))
+	systemRouter.Methods("PUT").Path("/api/providers/{provider}").HandlerFunc(provider.withScope(scopeConfigWrite, func(response http.ResponseWriter, request *http.Request) {
 		if provider.ReadOnly {
 			response.WriteHeader(http.StatusForbidden)
 			fmt.Fprintf(response, "REST API is in read-only mode")
@@ -74,20 +103,67 @@ func (provider *WebProvider) Provide(configurationChan chan<- types.ConfigMessag
 		err := json.Unmarshal(body, configuration)
 		if err == nil {
 			configurationChan <- types.ConfigMessage{"web", configuration}
+			configStream.publish("web", configuration)
 			provider.getConfigHandler(response, request)
 		} else {
 			log.Errorf("Error parsing configuration %+v", err)
 			http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
 		}
-	})
-	systemRouter.Methods("GET").Path("/api/providers/{provider}/backends").HandlerFunc(provider.getBackendsHandler)
-	systemRouter.Methods("GET").Path("/api/providers/{provider}/backends/{backend}").HandlerFunc(provider.getBackendHandler)
-	systemRouter.Methods("GET").Path("/api/providers/{provider}/backends/{backend}/servers").HandlerFunc(provider.getServersHandler)
-	systemRouter.Methods("GET").Path("/api/providers/{provider}/backends/{backend}/servers/{server}").HandlerFunc(provider.getServerHandler)
-	systemRouter.Methods("GET").Path("/api/providers/{provider}/frontends").HandlerFunc(provider.getFrontendsHandler)
-	systemRouter.Methods("GET").Path("/api/providers/{provider}/frontends/{frontend}").HandlerFunc(provider.getFrontendHandler)
-	systemRouter.Methods("GET").Path("/api/providers/{provider}/frontends/{frontend}/routes").HandlerFunc(provider.getRoutesHandler)
-	systemRouter.Methods("GET").Path("/api/providers/{provider}/frontends/{frontend}/routes/{route}").HandlerFunc(provider.getRouteHandler)
+	}))
+	systemRouter.Methods("GET").Path("/api/providers/{provider}/backends").HandlerFunc(provider.withScope(scopeConfigRead, provider.getBackendsHandler))
+	systemRouter.Methods("GET").Path("/api/providers/{provider}/backends/{backend}").HandlerFunc(provider.withScope(scopeConfigRead, provider.getBackendHandler))
+	systemRouter.Methods("GET").Path("/api/providers/{provider}/backends/{backend}/servers").HandlerFunc(provider.withScope(scopeConfigRead, provider.getServersHandler))
+	systemRouter.Methods("GET").Path("/api/providers/{provider}/backends/{backend}/servers/{server}").HandlerFunc(provider.withScope(scopeConfigRead, provider.getServerHandler))
+	systemRouter.Methods("GET").Path("/api/providers/{provider}/frontends").HandlerFunc(provider.withScope(scopeConfigRead, provider.getFrontendsHandler))
+	systemRouter.Methods("GET").Path("/api/providers/{provider}/frontends/{frontend}").HandlerFunc(provider.withScope(scopeConfigRead, provider.getFrontendHandler))
+	systemRouter.Methods("GET").Path("/api/providers/{provider}/frontends/{frontend}/routes").HandlerFunc(provider.withScope(scopeConfigRead, provider.getRoutesHandler))
+	systemRouter.Methods("GET").Path("/api/providers/{provider}/frontends/{frontend}/routes/{route}").HandlerFunc(provider.withScope(scopeConfigRead, provider.getRouteHandler))
+
+	// Targeted write API for the "rest" provider
+	systemRouter.Methods("PATCH").Path("/api/providers/rest").HandlerFunc(provider.withScope(scopeConfigWrite, provider.patchRestProviderHandler(configurationChan)))
+	systemRouter.Methods("PUT").Path("/api/providers/rest/backends/{backend}").HandlerFunc(provider.withScope(scopeConfigWrite, provider.putRestBackendHandler(configurationChan)))
+	systemRouter.Methods("DELETE").Path("/api/providers/rest/backends/{backend}").HandlerFunc(provider.withScope(scopeConfigWrite, provider.deleteRestBackendHandler(configurationChan)))
+	systemRouter.Methods("PUT").Path("/api/providers/rest/backends/{backend}/servers/{server}").HandlerFunc(provider.withScope(scopeConfigWrite, provider.putRestServerHandler(configurationChan)))
+	systemRouter.Methods("DELETE").Path("/api/providers/rest/backends/{backend}/servers/{server}").HandlerFunc(provider.withScope(scopeConfigWrite, provider.deleteRestServerHandler(configurationChan)))
+	systemRouter.Methods("PUT").Path("/api/providers/rest/frontends/{frontend}").HandlerFunc(provider.withScope(scopeConfigWrite, provider.putRestFrontendHandler(configurationChan)))
+	systemRouter.Methods("DELETE").Path("/api/providers/rest/frontends/{frontend}").HandlerFunc(provider.withScope(scopeConfigWrite, provider.deleteRestFrontendHandler(configurationChan)))
+	systemRouter.Methods("PUT").Path("/api/providers/rest/frontends/{frontend}/routes/{route}").HandlerFunc(provider.withScope(scopeConfigWrite, provider.putRestRouteHandler(configurationChan)))
+	systemRouter.Methods("DELETE").Path("/api/providers/rest/frontends/{frontend}/routes/{route}").HandlerFunc(provider.withScope(scopeConfigWrite, provider.deleteRestRouteHandler(configurationChan)))
+
+	// ACME routes
+	systemRouter.Methods("GET").Path("/api/acme/accounts").HandlerFunc(provider.withScope(scopeAcmeRead, provider.getAcmeAccountsHandler))
+	systemRouter.Methods("POST").Path("/api/acme/accounts").HandlerFunc(provider.withScope(scopeAcmeWrite, provider.createAcmeAccountHandler))
+	systemRouter.Methods("GET").Path("/api/acme/accounts/{id}").HandlerFunc(provider.withScope(scopeAcmeRead, provider.getAcmeAccountHandler))
+	systemRouter.Methods("DELETE").Path("/api/acme/accounts/{id}").HandlerFunc(provider.withScope(scopeAcmeWrite, provider.deleteAcmeAccountHandler))
+	systemRouter.Methods("POST").Path("/api/acme/accounts/{id}/external-binding").HandlerFunc(provider.withScope(scopeAcmeWrite, provider.bindAcmeAccountExternalHandler))
+
+	// Live configuration streaming. The "web" and "rest" handlers above
+	// publish to configStream themselves the moment they hand a new
+	// configuration to configurationChan, so /api/stream and /ws consumers
+	// see those changes with no polling delay. Configurations coming from
+	// other providers (file, docker, kubernetes, ...) are merged into
+	// currentConfigurations by the Server's own config-apply goroutine and
+	// are not published to configStream, so stream subscribers do not see
+	// them.
+	systemRouter.Methods("GET").Path("/api/stream").HandlerFunc(provider.withScope(scopeConfigRead, provider.streamHandler))
+	systemRouter.Methods("GET").Path("/ws").HandlerFunc(provider.withScope(scopeConfigRead, provider.wsHandler))
+
+	// Prometheus metrics
+	metricsEnabled := provider.Metrics != nil && provider.Metrics.Prometheus != nil && provider.Metrics.Prometheus.Enabled
+	if metricsEnabled {
+		configureMetricsBuckets(provider.Metrics.Prometheus.Buckets)
+		if entryPoint := provider.Metrics.Prometheus.EntryPoint; len(entryPoint) > 0 && entryPoint != provider.Address {
+			metricsRouter := mux.NewRouter()
+			metricsRouter.Methods("GET").Path("/metrics").HandlerFunc(provider.withScope(scopeMetricsRead, promhttp.Handler().ServeHTTP))
+			go func() {
+				if err := http.ListenAndServe(entryPoint, metricsRouter); err != nil {
+					log.Fatal("Error creating metrics server: ", err)
+				}
+			}()
+		} else {
+			systemRouter.Methods("GET").Path("/metrics").HandlerFunc(provider.withScope(scopeMetricsRead, promhttp.Handler().ServeHTTP))
+		}
+	}
 
 	// Expose dashboard
 	systemRouter.Methods("GET").Path("/").HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
@@ -100,15 +176,36 @@ func (provider *WebProvider) Provide(configurationChan chan<- types.ConfigMessag
 		systemRouter.Methods("GET").Path("/debug/vars").HandlerFunc(expvarHandler)
 	}
 
+	// The admin API's own traffic is labelled through WrapFrontend as
+	// frontend "traefik", backend "internal", and its connections are counted
+	// through newCountingListener under the admin listener's address as the
+	// entry point name. Proxied frontends and their entry points are not
+	// wrapped or counted.
+	var handler http.Handler = systemRouter
+	if metricsEnabled {
+		handler = WrapFrontend(systemRouter, "traefik", "internal")
+	}
+
 	go func() {
+		listener, err := net.Listen("tcp", provider.Address)
+		if err != nil {
+			log.Fatal("Error creating server: ", err)
+		}
+		if metricsEnabled {
+			listener = newCountingListener(listener, provider.Address)
+		}
+
 		if len(provider.CertFile) > 0 && len(provider.KeyFile) > 0 {
-			err := http.ListenAndServeTLS(provider.Address, provider.CertFile, provider.KeyFile, systemRouter)
+			config, err := buildTLSConfig(provider)
 			if err != nil {
 				log.Fatal("Error creating server: ", err)
 			}
+			listener = tls.NewListener(listener, config)
+			if err := http.Serve(listener, handler); err != nil {
+				log.Fatal("Error creating server: ", err)
+			}
 		} else {
-			err := http.ListenAndServe(provider.Address, systemRouter)
-			if err != nil {
+			if err := http.Serve(listener, handler); err != nil {
 				log.Fatal("Error creating server: ", err)
 			}
 		}
@@ -116,6 +213,45 @@ func (provider *WebProvider) Provide(configurationChan chan<- types.ConfigMessag
 	return nil
 }
 
+// buildTLSConfig loads the admin API's server certificate for the listener
+// started by Provide, and, when an MtlsAuth backend is configured, verifies
+// client certificates against its CA bundle so that
+// request.TLS.PeerCertificates is actually populated for MtlsAuth.validate.
+//
+// If Bearer or Basic is also configured, the handshake only requests a
+// client certificate instead of requiring one (tls.VerifyClientCertIfGiven):
+// requiring one unconditionally would stop bearer- or basic-only clients
+// from completing the handshake at all, which would silently break the
+// "backends are tried in order, first match wins" contract grantedScopes
+// implements. With no other backend configured, mTLS is the only way to
+// authenticate, so a certificate is required.
+func buildTLSConfig(provider *WebProvider) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(provider.CertFile, provider.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if provider.Auth != nil && provider.Auth.Mtls != nil {
+		caBundle, err := ioutil.ReadFile(provider.Auth.Mtls.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading mTLS CA file: %+v", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no certificates found in mTLS CA file %q", provider.Auth.Mtls.CAFile)
+		}
+		config.ClientCAs = clientCAs
+		if provider.Auth.Bearer != nil || provider.Auth.Basic != nil {
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+		} else {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return config, nil
+}
+
 func (provider *WebProvider) getHealthHandler(response http.ResponseWriter, request *http.Request) {
 	templatesRenderer.JSON(response, http.StatusOK, metrics.Data())
 }
@@ -248,6 +384,315 @@ func (provider *WebProvider) getRouteHandler(response http.ResponseWriter, reque
 	http.NotFound(response, request)
 }
 
+// acmeExternalAccountBinding holds the key-id / HMAC key pair a CA issues
+// out-of-band so that traefik can bind the account key it generated for an
+// account to an existing CA account, as described in RFC 8555 section
+// 7.3.4. The admin API caller supplies this secret, not the binding
+// signature: traefik computes the signature itself over its account key.
+type acmeExternalAccountBinding struct {
+	KeyID   string `json:"kid"`
+	HMACKey string `json:"hmacKey"`
+}
+
+// acmeExternalAccountBindingJWS is the flattened JWS traefik computes from
+// an acmeExternalAccountBinding and its own account key; it is the value
+// RFC 8555 section 7.3.4 requires as the newAccount request's
+// externalAccountBinding field when registering with the CA.
+type acmeExternalAccountBindingJWS struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// acmeAccount is the subset of RFC 8555 account fields traefik keeps
+// alongside the account key it generates on creation.
+type acmeAccount struct {
+	ID                     string                         `json:"id"`
+	Contact                []string                       `json:"contact,omitempty"`
+	TermsAgreed            bool                           `json:"termsAgreed,omitempty"`
+	OnlyReturnExisting     bool                           `json:"onlyReturnExisting,omitempty"`
+	ExternalAccountBinding *acmeExternalAccountBindingJWS `json:"externalAccountBinding,omitempty"`
+	URL                    string                         `json:"url"`
+	Key                    string                         `json:"key,omitempty"`
+}
+
+// acmeAccountStore holds the accounts created through the admin API in
+// memory for the lifetime of the process. It is guarded by a mutex as the
+// admin API handlers reach it from concurrent request goroutines. It is not
+// the store traefik's own ACME client keeps its account in, and its
+// accounts, account.Key included, do not survive a restart.
+type acmeAccountStore struct {
+	mu       sync.Mutex
+	accounts map[string]*acmeAccount
+}
+
+var acmeAccounts = &acmeAccountStore{accounts: map[string]*acmeAccount{}}
+
+func (s *acmeAccountStore) list() []*acmeAccount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accounts := make([]*acmeAccount, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
+func (s *acmeAccountStore) get(id string) (*acmeAccount, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.accounts[id]
+	return account, ok
+}
+
+func (s *acmeAccountStore) findByContact(contact []string) (*acmeAccount, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.accounts {
+		if sameContacts(account.Contact, contact) {
+			return account, true
+		}
+	}
+	return nil, false
+}
+
+func (s *acmeAccountStore) save(account *acmeAccount) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[account.ID] = account
+}
+
+func (s *acmeAccountStore) delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.accounts[id]; !ok {
+		return false
+	}
+	delete(s.accounts, id)
+	return true
+}
+
+func sameContacts(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// generateAcmeAccountKey creates the account key traefik signs ACME requests
+// with on behalf of the new account, returning both the key and its PEM
+// encoding for storage.
+func generateAcmeAccountKey() (*ecdsa.PrivateKey, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	return key, string(pemBytes), nil
+}
+
+// accountKeyJWK renders an EC account public key as the JWK object RFC 8555
+// section 7.3.4 requires as the EAB payload.
+func accountKeyJWK(pub *ecdsa.PublicKey) ([]byte, error) {
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	return json.Marshal(map[string]string{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(bigIntBytes(pub.X, size)),
+		"y":   base64.RawURLEncoding.EncodeToString(bigIntBytes(pub.Y, size)),
+	})
+}
+
+func bigIntBytes(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
+
+// computeExternalAccountBindingJWS builds the flattened JWS RFC 8555 section
+// 7.3.4 requires to bind accountKey to the CA account identified by
+// eab.KeyID: an HMAC-SHA256, keyed by the CA-issued hmacKey, over the
+// base64url-encoded {"alg":"HS256","kid":...,"url":...} protected header and
+// the account's public key rendered as a JWK. Callers pass the account key
+// traefik generated for the account (a fresh one on creation, the stored one
+// when binding later); no caller-supplied signature is verified.
+func computeExternalAccountBindingJWS(eab *acmeExternalAccountBinding, accountKey *ecdsa.PublicKey, url string) (*acmeExternalAccountBindingJWS, error) {
+	if eab.KeyID == "" || eab.HMACKey == "" {
+		return nil, fmt.Errorf("externalAccountBinding requires both kid and hmacKey")
+	}
+	key, err := base64.RawURLEncoding.DecodeString(eab.HMACKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid externalAccountBinding hmacKey: %+v", err)
+	}
+
+	protected, err := json.Marshal(map[string]string{
+		"alg": "HS256",
+		"kid": eab.KeyID,
+		"url": url,
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := accountKeyJWK(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding account key as JWK: %+v", err)
+	}
+
+	protectedB64 := base64.RawURLEncoding.EncodeToString(protected)
+	payloadB64 := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(protectedB64 + "." + payloadB64))
+
+	return &acmeExternalAccountBindingJWS{
+		Protected: protectedB64,
+		Payload:   payloadB64,
+		Signature: base64.RawURLEncoding.EncodeToString(mac.Sum(nil)),
+	}, nil
+}
+
+func (provider *WebProvider) getAcmeAccountsHandler(response http.ResponseWriter, request *http.Request) {
+	templatesRenderer.JSON(response, http.StatusOK, acmeAccounts.list())
+}
+
+func (provider *WebProvider) getAcmeAccountHandler(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	if account, ok := acmeAccounts.get(vars["id"]); ok {
+		templatesRenderer.JSON(response, http.StatusOK, account)
+		return
+	}
+	http.NotFound(response, request)
+}
+
+func (provider *WebProvider) createAcmeAccountHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.ReadOnly {
+		response.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(response, "REST API is in read-only mode")
+		return
+	}
+
+	var req struct {
+		Contact                []string                    `json:"contact,omitempty"`
+		TermsAgreed            bool                        `json:"termsAgreed,omitempty"`
+		OnlyReturnExisting     bool                        `json:"onlyReturnExisting,omitempty"`
+		ExternalAccountBinding *acmeExternalAccountBinding `json:"externalAccountBinding,omitempty"`
+	}
+	body, _ := ioutil.ReadAll(request.Body)
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Errorf("Error parsing ACME account %+v", err)
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.OnlyReturnExisting {
+		if existing, ok := acmeAccounts.findByContact(req.Contact); ok {
+			templatesRenderer.JSON(response, http.StatusOK, existing)
+			return
+		}
+		http.Error(response, "no existing account matches the given contact", http.StatusBadRequest)
+		return
+	}
+
+	account := &acmeAccount{
+		ID:                 uuid.NewV4().String(),
+		Contact:            req.Contact,
+		TermsAgreed:        req.TermsAgreed,
+		OnlyReturnExisting: req.OnlyReturnExisting,
+	}
+	account.URL = fmt.Sprintf("https://%s/api/acme/accounts/%s", request.Host, account.ID)
+
+	accountKey, keyPEM, err := generateAcmeAccountKey()
+	if err != nil {
+		log.Errorf("Error generating ACME account key %+v", err)
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+		return
+	}
+	account.Key = keyPEM
+
+	if req.ExternalAccountBinding != nil {
+		binding, err := computeExternalAccountBindingJWS(req.ExternalAccountBinding, &accountKey.PublicKey, account.URL)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+			return
+		}
+		account.ExternalAccountBinding = binding
+	}
+
+	acmeAccounts.save(account)
+	templatesRenderer.JSON(response, http.StatusOK, account)
+}
+
+func (provider *WebProvider) deleteAcmeAccountHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.ReadOnly {
+		response.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(response, "REST API is in read-only mode")
+		return
+	}
+
+	vars := mux.Vars(request)
+	if acmeAccounts.delete(vars["id"]) {
+		response.WriteHeader(http.StatusOK)
+		return
+	}
+	http.NotFound(response, request)
+}
+
+func (provider *WebProvider) bindAcmeAccountExternalHandler(response http.ResponseWriter, request *http.Request) {
+	if provider.ReadOnly {
+		response.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(response, "REST API is in read-only mode")
+		return
+	}
+
+	vars := mux.Vars(request)
+	account, ok := acmeAccounts.get(vars["id"])
+	if !ok {
+		http.NotFound(response, request)
+		return
+	}
+
+	eab := new(acmeExternalAccountBinding)
+	body, _ := ioutil.ReadAll(request.Body)
+	if err := json.Unmarshal(body, eab); err != nil {
+		log.Errorf("Error parsing external account binding %+v", err)
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+
+	block, _ := pem.Decode([]byte(account.Key))
+	if block == nil {
+		http.Error(response, "account has no usable key to bind", http.StatusInternalServerError)
+		return
+	}
+	accountKey, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusInternalServerError)
+		return
+	}
+
+	binding, err := computeExternalAccountBindingJWS(eab, &accountKey.PublicKey, account.URL)
+	if err != nil {
+		http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		return
+	}
+
+	account.ExternalAccountBinding = binding
+	acmeAccounts.save(account)
+	templatesRenderer.JSON(response, http.StatusOK, account)
+}
+
 func expvarHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	fmt.Fprintf(w, "{\n")